@@ -0,0 +1,46 @@
+package docdb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Aggregate runs pipeline against collection and decodes every resulting
+// document into results, a pointer to a slice. Use AggregateCursor instead
+// when the result set is too large to buffer in memory.
+func (db *MongoDB) Aggregate(ctx context.Context, collection string, pipeline []bson.M, results interface{}) error {
+	coll := db.GetCollection(collection)
+
+	cur, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return classifyError(err)
+	}
+	defer cur.Close(ctx)
+
+	if err := cur.All(ctx, results); err != nil {
+		return classifyError(err)
+	}
+	return nil
+}
+
+// AggregateCursor runs pipeline against collection and invokes handler once
+// per resulting document, streaming the cursor instead of buffering the
+// whole result set like Aggregate does. Iteration stops at the first error
+// handler returns.
+func (db *MongoDB) AggregateCursor(ctx context.Context, collection string, pipeline []bson.M, handler func(decode func(interface{}) error) error) error {
+	coll := db.GetCollection(collection)
+
+	cur, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return classifyError(err)
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		if err := handler(cur.Decode); err != nil {
+			return err
+		}
+	}
+	return classifyError(cur.Err())
+}
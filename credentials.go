@@ -0,0 +1,231 @@
+package docdb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Credentials is a username/password pair handed back by a CredentialProvider.
+// LeaseDuration is zero for credentials that never expire (e.g. a static URI);
+// a non-zero value schedules a background refresh ahead of expiry.
+type Credentials struct {
+	Username      string
+	Password      string
+	LeaseDuration time.Duration
+}
+
+// CredentialProvider supplies the username/password used to connect to MongoDB.
+// It is consulted on initial connect and again on reconnect, so implementations
+// backed by a secrets engine can rotate credentials without a process restart.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// StaticCredentialProvider returns a fixed, non-expiring set of credentials.
+// NewDB does not use a provider; pass this one to NewDBWithCredentials.
+type StaticCredentialProvider struct {
+	Username string
+	Password string
+}
+
+// Credentials implements CredentialProvider.
+func (p StaticCredentialProvider) Credentials(ctx context.Context) (Credentials, error) {
+	return Credentials{Username: p.Username, Password: p.Password}, nil
+}
+
+// FileCredentialProvider re-reads a "username\npassword" file on every call,
+// so credentials can be rotated by rewriting the file in place (e.g. a mounted
+// Kubernetes secret).
+type FileCredentialProvider struct {
+	Path string
+}
+
+// Credentials implements CredentialProvider.
+func (p FileCredentialProvider) Credentials(ctx context.Context) (Credentials, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("docdb: read credential file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Credentials{}, fmt.Errorf("docdb: read credential file: %w", err)
+	}
+	if len(lines) < 2 {
+		return Credentials{}, fmt.Errorf("docdb: credential file %s must contain username and password on separate lines", p.Path)
+	}
+
+	return Credentials{Username: lines[0], Password: lines[1]}, nil
+}
+
+// VaultSecretReader is the subset of the Vault API client used by
+// VaultCredentialProvider, so tests and alternative client versions can supply
+// their own implementation.
+type VaultSecretReader interface {
+	ReadCredentials(ctx context.Context, path string) (username, password string, leaseDuration time.Duration, err error)
+}
+
+// VaultCredentialProvider reads dynamic database credentials from a HashiCorp
+// Vault database secrets engine at Path (e.g. "database/creds/readwrite").
+type VaultCredentialProvider struct {
+	Client VaultSecretReader
+	Path   string
+}
+
+// Credentials implements CredentialProvider.
+func (p VaultCredentialProvider) Credentials(ctx context.Context) (Credentials, error) {
+	username, password, lease, err := p.Client.ReadCredentials(ctx, p.Path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("docdb: vault credential read: %w", err)
+	}
+	return Credentials{Username: username, Password: password, LeaseDuration: lease}, nil
+}
+
+// AWSSecretReader is the subset of an AWS Secrets Manager / IAM auth client
+// used by AWSCredentialProvider.
+type AWSSecretReader interface {
+	GetSecretValue(ctx context.Context, secretID string) (username, password string, err error)
+}
+
+// AWSCredentialProvider resolves credentials from AWS Secrets Manager (or an
+// IAM database-auth token generator wired up behind the same interface).
+// AWS-managed database secrets don't expose a lease duration, so RefreshEvery
+// should be set to the rotation window configured on the secret.
+type AWSCredentialProvider struct {
+	Client       AWSSecretReader
+	SecretID     string
+	RefreshEvery time.Duration
+}
+
+// Credentials implements CredentialProvider.
+func (p AWSCredentialProvider) Credentials(ctx context.Context) (Credentials, error) {
+	username, password, err := p.Client.GetSecretValue(ctx, p.SecretID)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("docdb: aws secret read: %w", err)
+	}
+	return Credentials{Username: username, Password: password, LeaseDuration: p.RefreshEvery}, nil
+}
+
+// NewDBWithCredentials creates a DB connection whose credentials are sourced
+// from provider instead of baked into uri. uri's userinfo, if any, is
+// discarded and replaced on every (re)connect with what provider returns.
+// When the returned Credentials carry a LeaseDuration, a background goroutine
+// refreshes the connection ahead of expiry; ForceReconnect lets callers (e.g.
+// an auth-failure handler) trigger the same rotation on demand.
+func NewDBWithCredentials(ctx context.Context, provider CredentialProvider, uri, database string) (db *MongoDB, err error) {
+	db = &MongoDB{
+		database:   database,
+		credential: provider,
+		baseURI:    uri,
+	}
+
+	if err = db.reconnect(ctx); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// reconnect fetches fresh credentials from db.credential, rewrites the
+// connection URI's userinfo, and swaps in a newly connected client. It also
+// (re)arms the background refresh goroutine for the new lease.
+func (db *MongoDB) reconnect(ctx context.Context) error {
+	creds, err := db.credential.Credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	uri, err := rewriteURIUserinfo(db.baseURI, creds.Username, creds.Password)
+	if err != nil {
+		return err
+	}
+
+	client, err := mongo.NewClient(options.Client().ApplyURI(uri))
+	if err != nil {
+		return err
+	}
+	if err := client.Connect(ctx); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	old := db.client
+	db.client = client
+	db.mu.Unlock()
+
+	if old != nil {
+		_ = old.Disconnect(ctx)
+	}
+
+	db.armRefresh(creds.LeaseDuration)
+	return nil
+}
+
+// ForceReconnect rotates the connection immediately, re-consulting the
+// credential provider. Callers typically invoke this after observing an auth
+// failure from the driver.
+func (db *MongoDB) ForceReconnect(ctx context.Context) error {
+	if db.credential == nil {
+		return fmt.Errorf("docdb: ForceReconnect requires a DB created with NewDBWithCredentials")
+	}
+	return db.reconnect(ctx)
+}
+
+// armRefresh (re)starts the background goroutine that calls reconnect shortly
+// before the current lease expires. A zero leaseDuration disables refresh.
+func (db *MongoDB) armRefresh(leaseDuration time.Duration) {
+	db.mu.Lock()
+	if db.refreshCancel != nil {
+		db.refreshCancel()
+		db.refreshCancel = nil
+	}
+	if leaseDuration <= 0 {
+		db.mu.Unlock()
+		return
+	}
+
+	refreshCtx, cancel := context.WithCancel(context.Background())
+	db.refreshCancel = cancel
+	db.mu.Unlock()
+
+	// Refresh at 2/3 of the lease so rotation completes before expiry.
+	delay := leaseDuration * 2 / 3
+
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-refreshCtx.Done():
+			return
+		case <-timer.C:
+			_ = db.reconnect(refreshCtx)
+		}
+	}()
+}
+
+// rewriteURIUserinfo returns uri with its userinfo replaced by username/password.
+func rewriteURIUserinfo(uri, username, password string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("docdb: parse connection uri: %w", err)
+	}
+	parsed.User = url.UserPassword(username, password)
+	return parsed.String(), nil
+}
@@ -3,7 +3,9 @@ package docdb
 import (
 	"context"
 	"errors"
+	"sync"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -22,6 +24,30 @@ type DBIntf interface {
 	UpdateItems(ctx context.Context, c string, match map[string]interface{}, update map[string]interface{}) (int64, error)
 	GetCollection(collection string) *mongo.Collection
 	GetClient() *mongo.Client
+	// WithTransaction runs fn inside a mongo session/transaction. fn must use the
+	// sessCtx it is given when calling Save/Update*/Delete* for those calls to
+	// participate in the transaction.
+	WithTransaction(ctx context.Context, fn func(sessCtx context.Context) error, opts ...*options.TransactionOptions) error
+	StartSession(opts ...*options.SessionOptions) (mongo.Session, error)
+	Aggregate(ctx context.Context, collection string, pipeline []bson.M, results interface{}) error
+	AggregateCursor(ctx context.Context, collection string, pipeline []bson.M, handler func(decode func(interface{}) error) error) error
+	Watch(ctx context.Context, collection string, pipeline []bson.M, opts *WatchOptions) (*Watcher, error)
+
+	// Find/Insert/Update/Delete are the fluent builder entry points
+	// (query.go); they stay on DBIntf so callers that construct a DB through
+	// NewDBWithOptions don't lose access to them.
+	Find(collection string) *FindOperation
+	Insert(collection string) *InsertOperation
+	Update(collection string) *UpdateOperation
+	Delete(collection string) *DeleteOperation
+
+	EnsureSchema(ctx context.Context, specs []CollectionSpec) error
+	Migrator() *Migrator
+
+	Healthy() bool
+	LastError() error
+	ForceReconnect(ctx context.Context) error
+	StartHealthChecker(opts HealthCheckOptions) *HealthChecker
 }
 
 // ErrMongoDBDuplicate error
@@ -35,11 +61,32 @@ var ErrNotFound = errors.New("item not found")
 
 // MongoDB connection holder
 type MongoDB struct {
+	mu       sync.Mutex
 	client   *mongo.Client
 	database string
+
+	// credential is set when the connection was created via
+	// NewDBWithCredentials and drives reconnect/ForceReconnect; it is nil for
+	// connections created with the plain NewDB constructor. baseURI is set by
+	// both constructors and is what the health checker redials.
+	credential    CredentialProvider
+	baseURI       string
+	refreshCancel context.CancelFunc
+
+	// health is set by StartHealthChecker; Ping consults it when present so
+	// repeated calls don't hammer the server.
+	health *HealthChecker
+
+	// migrator is set by the first call to Migrator and reused by every
+	// later one, so independent packages registering migrations against the
+	// same db accumulate into one Migrator instead of each resetting it.
+	migrator *Migrator
 }
 
-// NewDB creates a DB connection and returns a db instance
+// NewDB creates a DB connection and returns a db instance. Credentials, if
+// any, are taken from uri's userinfo and never rotate; use
+// NewDBWithCredentials for rotating credentials (Vault, AWS, a mounted
+// secret file, ...).
 func NewDB(ctx context.Context, uri, database string) (db *MongoDB, err error) {
 	db = &MongoDB{}
 
@@ -54,31 +101,56 @@ func NewDB(ctx context.Context, uri, database string) (db *MongoDB, err error) {
 
 	db.database = database
 	db.client = client
+	db.baseURI = uri
 	return
 }
 
 // Disconnect closes the mongodb connection
 func (db *MongoDB) Disconnect(ctx context.Context) {
-	db.client.Disconnect(ctx)
+	db.mu.Lock()
+	health := db.health
+	db.health = nil
+	if db.refreshCancel != nil {
+		db.refreshCancel()
+		db.refreshCancel = nil
+	}
+	db.mu.Unlock()
+
+	if health != nil {
+		health.Stop()
+	}
+
+	db.GetClient().Disconnect(ctx)
 }
 
-// Ping db
+// Ping db. If a HealthChecker is running (see StartHealthChecker), this
+// returns its cached state instead of issuing a new ping; otherwise it pings
+// the primary directly.
 func (db *MongoDB) Ping(ctx context.Context) (bool, error) {
+	db.mu.Lock()
+	h := db.health
+	db.mu.Unlock()
+	if h != nil {
+		return h.Healthy(), h.LastError()
+	}
+
 	err := db.client.Ping(ctx, readpref.Primary())
 	if err != nil {
-		return false, err
+		return false, classifyError(err)
 	}
 	return true, nil
 }
 
 // GetClient func
 func (db *MongoDB) GetClient() *mongo.Client {
+	db.mu.Lock()
+	defer db.mu.Unlock()
 	return db.client
 }
 
 // GetCollection func
 func (db *MongoDB) GetCollection(collection string) *mongo.Collection {
-	return db.client.Database(db.database).Collection(collection)
+	return db.GetClient().Database(db.database).Collection(collection)
 }
 
 // Save func: c stands for collection where data would be saved. e.g save data in 'users' collection in MongoDB
@@ -89,14 +161,7 @@ func (db *MongoDB) Save(ctx context.Context, c string, data interface{}) (string
 	// ctx can be a mongodb session context for transactions
 	insertResult, err := collection.InsertOne(ctx, data)
 	if err != nil {
-		/*
-			var merr mongo.WriteException
-			merr = err.(mongo.WriteException)
-			errCode := merr.WriteErrors[0].Code
-			if errCode == 11000 {
-				return "", ErrMongoDBDuplicate
-			} */
-		return "", err
+		return "", classifyError(err)
 	}
 	// update rule with returned ID
 	return insertResult.InsertedID.(primitive.ObjectID).Hex(), nil
@@ -109,7 +174,7 @@ func (db *MongoDB) SaveMultiple(ctx context.Context, c string, items []interface
 
 	insertManyResult, err := collection.InsertMany(ctx, items)
 	if err != nil {
-		return nil, err
+		return nil, classifyError(err)
 	}
 
 	return insertManyResult.InsertedIDs, nil
@@ -118,118 +183,58 @@ func (db *MongoDB) SaveMultiple(ctx context.Context, c string, items []interface
 // GetItem func: c stands for collection where item should be retrieved. e.g retrieve item from 'users' collection in MongoDB.
 // ctx can be a mongodb session context for transactions
 // results is a pointer to object to store returned data. nil is returned for error if item is found
+//
+// GetItem is a thin wrapper over Find for backwards compatibility; new code
+// should call Find directly.
 func (db *MongoDB) GetItem(ctx context.Context, c string, filter map[string]interface{}, excludedFields map[string]interface{}, result interface{}) error {
-	collection := db.GetCollection(c)
-
-	findOptions := options.FindOne().SetProjection(excludedFields)
-
-	// var result interface{}
-
-	err := collection.FindOne(ctx, filter, findOptions).Decode(result)
-	if err != nil {
-		// TODO check for not found errror and return it
-		// return nil, ErrNotFound
-		return err
-	}
-
-	return nil
+	return db.Find(c).Where(filter).Project(excludedFields).One(ctx, result)
 }
 
+// UpdateItem is a thin wrapper over Update for backwards compatibility; new
+// code should call Update directly.
 func (db *MongoDB) UpdateItem(ctx context.Context, c string, match map[string]interface{}, update map[string]interface{}) (int64, error) {
-	collection := db.GetCollection(c)
-
-	result, err := collection.UpdateOne(
-		ctx,
-		match,
-		update,
-	)
-	if err != nil {
-		return 0, err
-	}
-
-	return result.ModifiedCount, nil
+	return db.Update(c).Where(match).Apply(update).One(ctx)
 }
 
+// UpdateItems is a thin wrapper over Update for backwards compatibility; new
+// code should call Update directly.
 func (db *MongoDB) UpdateItems(ctx context.Context, c string, match map[string]interface{}, update map[string]interface{}) (int64, error) {
-	collection := db.GetCollection(c)
-
-	result, err := collection.UpdateMany(
-		ctx,
-		match,
-		update,
-	)
-	if err != nil {
-		return 0, err
-	}
-
-	return result.ModifiedCount, nil
+	return db.Update(c).Where(match).Apply(update).Many(ctx)
 }
 
 // GetItems func: c stands for collection where data would be saved. e.g save data in 'users' collection in MongoDB. id is string
 // ctx can be a mongodb session context for transactions
 // results is a pointer to slice of object to store returned data. nil is returned for error if item is found
+//
+// GetItems is a thin wrapper over Find for backwards compatibility; new code
+// should call Find directly.
 func (db *MongoDB) GetItems(ctx context.Context, c string, filter map[string]interface{}, limit int64, excludedFields map[string]interface{}, sort map[string]interface{}, results interface{}) error {
-	collection := db.GetCollection(c)
-
-	findOptions := options.Find().SetProjection(excludedFields)
-	findOptions.SetSort(sort)
-	findOptions.SetLimit(limit)
-
-	// var results []interface{}
-
-	cur, err := collection.Find(ctx, filter, findOptions)
-	if err != nil {
-		return err
-	}
-	// Close the cursor once finished
-	defer cur.Close(ctx)
-
-	if err := cur.All(ctx, results); err != nil {
-		return err
-	}
-
-	return nil
+	return db.Find(c).Where(filter).Project(excludedFields).Sort(sort).Limit(limit).All(ctx, results)
 }
 
 // CountItems func: c stands for collection where items should be counted. e.g count items in 'users' collection in MongoDB.
 // ctx can be a mongodb session context for transactions
+//
+// CountItems is a thin wrapper over Find for backwards compatibility; new
+// code should call Find directly.
 func (db *MongoDB) CountItems(ctx context.Context, c string, filter map[string]interface{}) (int64, error) {
-	collection := db.GetCollection(c)
-
-	countOptions := options.Count()
-
-	var result int64
-
-	result, err := collection.CountDocuments(ctx, filter, countOptions)
-	if err != nil {
-		return 0, err
-	}
-
-	return result, nil
+	return db.Find(c).Where(filter).Count(ctx)
 }
 
 // DeleteItem func: c stands for collection where item should be retrieved. e.g retrieve item from 'users' collection in MongoDB.
 // ctx can be a mongodb session context for transactions
+//
+// DeleteItem is a thin wrapper over Delete for backwards compatibility; new
+// code should call Delete directly.
 func (db *MongoDB) DeleteItem(ctx context.Context, c string, filter map[string]interface{}) (int64, error) {
-	collection := db.GetCollection(c)
-
-	deleteResult, err := collection.DeleteOne(ctx, filter)
-	if err != nil {
-		return 0, err
-	}
-
-	return deleteResult.DeletedCount, nil
+	return db.Delete(c).Where(filter).One(ctx)
 }
 
 // DeleteItems func: c stands for collection where item should be retrieved. e.g retrieve item from 'users' collection in MongoDB.
 // ctx can be a mongodb session context for transactions
+//
+// DeleteItems is a thin wrapper over Delete for backwards compatibility; new
+// code should call Delete directly.
 func (db *MongoDB) DeleteItems(ctx context.Context, c string, filter map[string]interface{}) (int64, error) {
-	collection := db.GetCollection(c)
-
-	deleteResult, err := collection.DeleteMany(ctx, filter)
-	if err != nil {
-		return 0, err
-	}
-
-	return deleteResult.DeletedCount, nil
+	return db.Delete(c).Where(filter).Many(ctx)
 }
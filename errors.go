@@ -0,0 +1,99 @@
+package docdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrWriteConflict error: the operation lost a write conflict with another
+// transaction and may be retried.
+var ErrWriteConflict = errors.New("write conflict")
+
+// ErrNetwork error: the driver could not reach the server (connection
+// refused, DNS failure, topology with no reachable nodes, ...).
+var ErrNetwork = errors.New("network error")
+
+// ErrTimeout error: the operation exceeded its context deadline or
+// server-side maxTimeMS.
+var ErrTimeout = errors.New("operation timed out")
+
+// duplicateKeyCode is the MongoDB server error code for a unique-index
+// violation (E11000).
+const duplicateKeyCode = 11000
+
+// classifyError maps a raw mongo-driver error to one of docdb's sentinel
+// errors (ErrMongoDBDuplicate, ErrNotFound, ErrInvalidObjectID,
+// ErrWriteConflict, ErrNetwork, ErrTimeout) so callers can use errors.Is
+// instead of type-asserting driver internals. Recognized errors are wrapped
+// so errors.Is/As still sees the original; errors it doesn't recognize are
+// returned unchanged.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	if errors.Is(err, primitive.ErrInvalidHex) {
+		return fmt.Errorf("%w: %w", ErrInvalidObjectID, err)
+	}
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		switch {
+		case cmdErr.Code == duplicateKeyCode:
+			return fmt.Errorf("%w: %w", ErrMongoDBDuplicate, err)
+		case cmdErr.HasErrorLabel("TransientTransactionError"):
+			return fmt.Errorf("%w: %w", ErrWriteConflict, err)
+		case cmdErr.HasErrorLabel("NetworkError"):
+			return fmt.Errorf("%w: %w", ErrNetwork, err)
+		}
+	}
+
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		if isDuplicateKeyWriteException(writeErr) {
+			return fmt.Errorf("%w: %w", ErrMongoDBDuplicate, err)
+		}
+		if writeErr.WriteConcernError != nil && writeErr.WriteConcernError.Code == duplicateKeyCode {
+			return fmt.Errorf("%w: %w", ErrMongoDBDuplicate, err)
+		}
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) {
+		for _, we := range bulkErr.WriteErrors {
+			if we.Code == duplicateKeyCode {
+				return fmt.Errorf("%w: %w", ErrMongoDBDuplicate, err)
+			}
+		}
+	}
+
+	if mongo.IsNetworkError(err) {
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	if mongo.IsTimeout(err) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	return err
+}
+
+// isDuplicateKeyWriteException reports whether a single-document write
+// failed on a unique-index violation.
+func isDuplicateKeyWriteException(we mongo.WriteException) bool {
+	for _, writeErr := range we.WriteErrors {
+		if writeErr.Code == duplicateKeyCode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package docdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "nil", err: nil, want: nil},
+		{name: "no documents", err: mongo.ErrNoDocuments, want: ErrNotFound},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: ErrTimeout},
+		{name: "invalid hex", err: primitive.ErrInvalidHex, want: ErrInvalidObjectID},
+		{
+			name: "command error duplicate key",
+			err:  mongo.CommandError{Code: duplicateKeyCode},
+			want: ErrMongoDBDuplicate,
+		},
+		{
+			name: "command error transient transaction",
+			err:  mongo.CommandError{Labels: []string{"TransientTransactionError"}},
+			want: ErrWriteConflict,
+		},
+		{
+			name: "command error network",
+			err:  mongo.CommandError{Labels: []string{"NetworkError"}},
+			want: ErrNetwork,
+		},
+		{
+			name: "write exception duplicate key",
+			err: mongo.WriteException{
+				WriteErrors: mongo.WriteErrors{{Code: duplicateKeyCode}},
+			},
+			want: ErrMongoDBDuplicate,
+		},
+		{
+			name: "bulk write exception duplicate key",
+			err: mongo.BulkWriteException{
+				WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: duplicateKeyCode}}},
+			},
+			want: ErrMongoDBDuplicate,
+		},
+		{
+			name: "unrecognized error is returned unchanged",
+			err:  errors.New("boom"),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError(tt.err)
+			if tt.err == nil {
+				if got != nil {
+					t.Fatalf("classifyError(nil) = %v, want nil", got)
+				}
+				return
+			}
+			if tt.want == nil {
+				if !errors.Is(got, tt.err) {
+					t.Fatalf("classifyError(%v) = %v, want it to wrap the original error", tt.err, got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.want) {
+				t.Fatalf("classifyError(%v) = %v, want errors.Is(_, %v)", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package docdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// DefaultHealthCheckInterval is how often the health checker pings the
+// primary when HealthCheckOptions.Interval is left at zero.
+const DefaultHealthCheckInterval = 2 * time.Second
+
+// DefaultHealthFailureThreshold is how many consecutive ping failures the
+// health checker tolerates before reconnecting, when
+// HealthCheckOptions.FailureThreshold is left at zero.
+const DefaultHealthFailureThreshold = 3
+
+// HealthCheckOptions configures a HealthChecker.
+type HealthCheckOptions struct {
+	// Interval between pings. Defaults to DefaultHealthCheckInterval.
+	Interval time.Duration
+	// FailureThreshold is the number of consecutive failed pings that
+	// triggers a reconnect. Defaults to DefaultHealthFailureThreshold.
+	FailureThreshold int
+}
+
+// HealthEvent is published on a HealthChecker's subscriber channels whenever
+// the connection transitions between healthy and unhealthy.
+type HealthEvent struct {
+	Healthy bool
+	Err     error
+	Time    time.Time
+}
+
+// HealthChecker periodically pings the primary and reconnects after too many
+// consecutive failures, mirroring a watchdog any long-lived mongo client
+// needs in front of it.
+type HealthChecker struct {
+	db   *MongoDB
+	opts HealthCheckOptions
+
+	mu          sync.RWMutex
+	healthy     bool
+	lastErr     error
+	failures    int
+	subscribers []chan HealthEvent
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartHealthChecker starts a background goroutine that pings db every
+// opts.Interval and reconnects (using the URI the DB was created with) after
+// opts.FailureThreshold consecutive failures. Call Stop on the returned
+// checker to release it.
+func (db *MongoDB) StartHealthChecker(opts HealthCheckOptions) *HealthChecker {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultHealthCheckInterval
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = DefaultHealthFailureThreshold
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &HealthChecker{
+		db:      db,
+		opts:    opts,
+		healthy: true,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	db.mu.Lock()
+	db.health = h
+	db.mu.Unlock()
+
+	go h.run(ctx)
+	return h
+}
+
+// Healthy reports the health checker's cached state, so callers can poll it
+// without hitting the server on every call.
+func (h *HealthChecker) Healthy() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.healthy
+}
+
+// LastError returns the error from the most recent failed ping, or nil if
+// the most recent ping succeeded. It can be non-nil while Healthy still
+// reports true, until FailureThreshold consecutive pings have failed.
+func (h *HealthChecker) LastError() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastErr
+}
+
+// Subscribe registers ch to receive a HealthEvent on every healthy/unhealthy
+// transition. Sends don't block: an event is dropped for a channel that isn't
+// ready to receive it. ch is closed when the checker is stopped.
+func (h *HealthChecker) Subscribe(ch chan HealthEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers = append(h.subscribers, ch)
+}
+
+// Stop ends the background ping loop and closes subscriber channels.
+func (h *HealthChecker) Stop() {
+	h.cancel()
+	<-h.done
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range h.subscribers {
+		close(ch)
+	}
+	h.subscribers = nil
+}
+
+func (h *HealthChecker) run(ctx context.Context) {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.check(ctx)
+		}
+	}
+}
+
+func (h *HealthChecker) check(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, h.opts.Interval)
+	defer cancel()
+
+	_, err := h.db.rawPing(pingCtx)
+
+	h.mu.Lock()
+	wasHealthy := h.healthy
+	if err == nil {
+		h.failures = 0
+		h.healthy = true
+		h.lastErr = nil
+	} else {
+		h.failures++
+		h.lastErr = classifyError(err)
+		if h.failures >= h.opts.FailureThreshold {
+			h.healthy = false
+		}
+	}
+	becameUnhealthy := wasHealthy && !h.healthy
+	becameHealthy := !wasHealthy && h.healthy
+	h.mu.Unlock()
+
+	if becameUnhealthy || becameHealthy {
+		h.publish(HealthEvent{Healthy: h.Healthy(), Err: h.LastError(), Time: time.Now()})
+	}
+
+	if becameUnhealthy {
+		_ = h.db.reconnectToBaseURI(ctx)
+	}
+}
+
+func (h *HealthChecker) publish(ev HealthEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, ch := range h.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// rawPing performs an uncached ping against the primary.
+func (db *MongoDB) rawPing(ctx context.Context) (bool, error) {
+	client := db.GetClient()
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reconnectToBaseURI redials using the credential provider if one was
+// configured (NewDBWithCredentials), or the original static URI otherwise
+// (plain NewDB). It is the reconnect path driven by the HealthChecker.
+func (db *MongoDB) reconnectToBaseURI(ctx context.Context) error {
+	if db.credential != nil {
+		return db.reconnect(ctx)
+	}
+
+	client, err := mongo.NewClient(options.Client().ApplyURI(db.baseURI))
+	if err != nil {
+		return err
+	}
+	if err := client.Connect(ctx); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	old := db.client
+	db.client = client
+	db.mu.Unlock()
+
+	if old != nil {
+		_ = old.Disconnect(ctx)
+	}
+	return nil
+}
+
+// Healthy reports whether the DB is considered healthy. It reflects the
+// HealthChecker's cached state if one was started with StartHealthChecker,
+// and otherwise always reports true (no checker means no opinion).
+func (db *MongoDB) Healthy() bool {
+	db.mu.Lock()
+	h := db.health
+	db.mu.Unlock()
+	if h == nil {
+		return true
+	}
+	return h.Healthy()
+}
+
+// LastError returns the most recent health-check failure, or nil if the
+// most recent ping succeeded or no HealthChecker is running.
+func (db *MongoDB) LastError() error {
+	db.mu.Lock()
+	h := db.health
+	db.mu.Unlock()
+	if h == nil {
+		return nil
+	}
+	return h.LastError()
+}
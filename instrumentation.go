@@ -0,0 +1,508 @@
+package docdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the OpenTelemetry tracer name every docdb span is
+// reported under.
+const instrumentationName = "simplifyd-systems/docdb"
+
+// Option configures NewDBWithOptions.
+type Option func(*dbOptions)
+
+type dbOptions struct {
+	tracerProvider     trace.TracerProvider
+	registerer         prometheus.Registerer
+	slowQueryThreshold time.Duration
+	logger             *log.Logger
+}
+
+// WithTracer sets the OpenTelemetry TracerProvider spans are reported
+// through. Defaults to otel.GetTracerProvider(), which is a no-op until the
+// application installs a real one.
+func WithTracer(tp trace.TracerProvider) Option {
+	return func(o *dbOptions) { o.tracerProvider = tp }
+}
+
+// WithMeter sets the Prometheus registerer docdb's counters/histograms are
+// registered against. Defaults to prometheus.DefaultRegisterer.
+func WithMeter(reg prometheus.Registerer) Option {
+	return func(o *dbOptions) { o.registerer = reg }
+}
+
+// WithSlowQueryThreshold enables warn-level logging of operations (filter,
+// projection, and sort included) that take at least d. Zero (the default)
+// disables slow-query logging.
+func WithSlowQueryThreshold(d time.Duration) Option {
+	return func(o *dbOptions) { o.slowQueryThreshold = d }
+}
+
+// WithLogger sets the logger slow-query warnings are written to. Defaults
+// to log.Default().
+func WithLogger(logger *log.Logger) Option {
+	return func(o *dbOptions) { o.logger = logger }
+}
+
+// NewDBWithOptions creates a DB connection wrapped with OpenTelemetry
+// tracing and Prometheus metrics around DBIntf's database operations (see
+// instrumentedDB for the methods passed through unwrapped). With no options
+// it still emits spans (to the no-op global tracer provider) and registers
+// metrics against prometheus.DefaultRegisterer, so existing callers that
+// migrate to it are unaffected until they install a real TracerProvider.
+func NewDBWithOptions(ctx context.Context, uri, database string, opts ...Option) (DBIntf, error) {
+	db, err := NewDB(ctx, uri, database)
+	if err != nil {
+		return nil, err
+	}
+	return instrument(db, opts...), nil
+}
+
+// NewDBWithCredentialsAndOptions is NewDBWithCredentials wrapped the same way
+// NewDBWithOptions wraps NewDB, for callers that need rotating credentials
+// and instrumentation together.
+func NewDBWithCredentialsAndOptions(ctx context.Context, provider CredentialProvider, uri, database string, opts ...Option) (DBIntf, error) {
+	db, err := NewDBWithCredentials(ctx, provider, uri, database)
+	if err != nil {
+		return nil, err
+	}
+	return instrument(db, opts...), nil
+}
+
+// instrumentedDB wraps a *MongoDB, adding a span, a duration/error/
+// documents-affected metric observation, and optional slow-query logging
+// around DBIntf's database operations. GetCollection, GetClient,
+// StartSession, Healthy, LastError and StartHealthChecker are passed through
+// without them.
+type instrumentedDB struct {
+	*MongoDB
+
+	tracer             trace.Tracer
+	duration           *prometheus.HistogramVec
+	errors             *prometheus.CounterVec
+	documentsAffected  *prometheus.HistogramVec
+	slowQueryThreshold time.Duration
+	logger             *log.Logger
+
+	// migratorOnce/migrator cache the instrumented Migrator the same way
+	// MongoDB.migrator does for the uninstrumented path, so registrations
+	// from multiple packages accumulate into one Migrator instead of each
+	// resetting it.
+	migratorOnce sync.Once
+	migrator     *Migrator
+}
+
+// dbCore is the minimal surface the query.go builders (FindOperation,
+// InsertOperation, UpdateOperation, DeleteOperation) need from the db they
+// were built from. *MongoDB satisfies it with a no-op observe so the
+// builders work unwrapped; *instrumentedDB satisfies it with the real
+// observe, so a builder obtained from an instrumented DB is metered without
+// the builder types needing to know which concrete db they're bound to.
+type dbCore interface {
+	GetCollection(collection string) *mongo.Collection
+	observe(ctx context.Context, operation, collection string, query queryShape, fn func(ctx context.Context) (float64, error)) (float64, error)
+}
+
+// observe on the plain MongoDB is a passthrough: it exists only so
+// FindOperation/InsertOperation/UpdateOperation/DeleteOperation can call
+// db.observe unconditionally regardless of whether db is instrumented.
+func (db *MongoDB) observe(ctx context.Context, operation, collection string, query queryShape, fn func(ctx context.Context) (float64, error)) (float64, error) {
+	return fn(ctx)
+}
+
+// dbMetrics is the set of collectors registered against one
+// prometheus.Registerer. It's kept separate from instrumentedDB so distinct
+// DBs that share a registerer (the common case, via prometheus.
+// DefaultRegisterer) also share one set of collectors.
+type dbMetrics struct {
+	duration          *prometheus.HistogramVec
+	errors            *prometheus.CounterVec
+	documentsAffected *prometheus.HistogramVec
+}
+
+// metricsMu guards metricsByReg: collectors are created and registered at
+// most once per distinct Registerer, not once per process, so two DBs using
+// WithMeter with different custom registries each get their own metrics
+// instead of the second one silently going unregistered.
+var (
+	metricsMu    sync.Mutex
+	metricsByReg = map[prometheus.Registerer]*dbMetrics{}
+)
+
+func registerMetrics(reg prometheus.Registerer) *dbMetrics {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	if m, ok := metricsByReg[reg]; ok {
+		return m
+	}
+
+	m := &dbMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "docdb_operation_duration_seconds",
+			Help: "Duration of docdb operations in seconds.",
+		}, []string{"operation", "collection"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "docdb_operation_errors_total",
+			Help: "Count of docdb operations that returned an error, by error class.",
+		}, []string{"operation", "collection", "error_class"}),
+		documentsAffected: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "docdb_documents_affected",
+			Help: "Number of documents inserted, matched, modified, or deleted per docdb operation.",
+		}, []string{"operation", "collection"}),
+	}
+	reg.MustRegister(m.duration, m.errors, m.documentsAffected)
+	metricsByReg[reg] = m
+	return m
+}
+
+func instrument(db *MongoDB, opts ...Option) DBIntf {
+	cfg := dbOptions{
+		tracerProvider: otel.GetTracerProvider(),
+		registerer:     prometheus.DefaultRegisterer,
+		logger:         log.Default(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	m := registerMetrics(cfg.registerer)
+
+	return &instrumentedDB{
+		MongoDB:            db,
+		tracer:             cfg.tracerProvider.Tracer(instrumentationName),
+		duration:           m.duration,
+		errors:             m.errors,
+		documentsAffected:  m.documentsAffected,
+		slowQueryThreshold: cfg.slowQueryThreshold,
+		logger:             cfg.logger,
+	}
+}
+
+// queryShape is the filter/projection/sort an instrumented call logs if it
+// turns out to be slow. Any of the three may be nil.
+type queryShape struct {
+	Filter     interface{}
+	Projection interface{}
+	Sort       interface{}
+}
+
+// observe wraps fn with a span, duration/error/documents-affected metrics,
+// and slow-query logging. fn returns the number of documents the operation
+// touched, used for the docdb_documents_affected histogram. Operations that
+// don't count the documents they read or stream (GetItems, Find.All,
+// Find.Stream, Aggregate, AggregateCursor, Watch) and the schema,
+// transaction and reconnect calls report 0.
+func (i *instrumentedDB) observe(ctx context.Context, operation, collection string, query queryShape, fn func(ctx context.Context) (float64, error)) (float64, error) {
+	ctx, span := i.tracer.Start(ctx, "docdb."+operation, trace.WithAttributes(
+		attribute.String("db.operation", operation),
+		attribute.String("db.collection", collection),
+		attribute.String("db.filter_shape", shapeHash(query.Filter)),
+	))
+	defer span.End()
+
+	start := time.Now()
+	documents, err := fn(ctx)
+	elapsed := time.Since(start)
+
+	i.duration.WithLabelValues(operation, collection).Observe(elapsed.Seconds())
+
+	if err != nil {
+		class := errorClass(err)
+		i.errors.WithLabelValues(operation, collection, class).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, class)
+	} else {
+		i.documentsAffected.WithLabelValues(operation, collection).Observe(documents)
+		span.SetAttributes(attribute.Int64("db.documents_affected", int64(documents)))
+	}
+
+	if i.slowQueryThreshold > 0 && elapsed >= i.slowQueryThreshold {
+		i.logger.Printf("[WARN] docdb: slow query op=%s collection=%s duration=%s filter=%v projection=%v sort=%v",
+			operation, collection, elapsed, query.Filter, query.Projection, query.Sort)
+	}
+
+	return documents, err
+}
+
+// shapeHash summarizes a filter's shape (its top-level field names and the
+// types of their values, not the values) so high-cardinality filter values
+// never end up as span attributes. Nested operator documents are not looked
+// into, and a value that isn't a map (an aggregation pipeline, for one)
+// hashes by its Go type alone.
+func shapeHash(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%T:%v", v, shapeOf(v))))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// shapeOf replaces a map's values with their types so two filters with the
+// same fields but different values hash identically.
+func shapeOf(v interface{}) interface{} {
+	switch val := v.(type) {
+	case bson.M:
+		return shapeOf(map[string]interface{}(val))
+	case map[string]interface{}:
+		shaped := make(map[string]string, len(val))
+		for k, fv := range val {
+			shaped[k] = fmt.Sprintf("%T", fv)
+		}
+		return shaped
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// errorClass reports which docdb sentinel error, if any, err classifies as,
+// for use as a low-cardinality metric label.
+func errorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrMongoDBDuplicate):
+		return "duplicate"
+	case errors.Is(err, ErrWriteConflict):
+		return "write_conflict"
+	case errors.Is(err, ErrNetwork):
+		return "network"
+	case errors.Is(err, ErrTimeout):
+		return "timeout"
+	case errors.Is(err, ErrInvalidObjectID):
+		return "invalid_object_id"
+	default:
+		return "other"
+	}
+}
+
+func (i *instrumentedDB) Save(ctx context.Context, collection string, data interface{}) (string, error) {
+	var id string
+	_, err := i.observe(ctx, "Save", collection, queryShape{}, func(ctx context.Context) (float64, error) {
+		var err error
+		id, err = i.MongoDB.Save(ctx, collection, data)
+		if err != nil {
+			return 0, err
+		}
+		return 1, nil
+	})
+	return id, err
+}
+
+func (i *instrumentedDB) SaveMultiple(ctx context.Context, collection string, data []interface{}) ([]interface{}, error) {
+	var ids []interface{}
+	_, err := i.observe(ctx, "SaveMultiple", collection, queryShape{}, func(ctx context.Context) (float64, error) {
+		var err error
+		ids, err = i.MongoDB.SaveMultiple(ctx, collection, data)
+		if err != nil {
+			return 0, err
+		}
+		return float64(len(ids)), nil
+	})
+	return ids, err
+}
+
+func (i *instrumentedDB) GetItem(ctx context.Context, collection string, filter map[string]interface{}, excludedFields map[string]interface{}, result interface{}) error {
+	_, err := i.observe(ctx, "GetItem", collection, queryShape{Filter: filter, Projection: excludedFields}, func(ctx context.Context) (float64, error) {
+		if err := i.MongoDB.GetItem(ctx, collection, filter, excludedFields, result); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	})
+	return err
+}
+
+func (i *instrumentedDB) GetItems(ctx context.Context, collection string, filter map[string]interface{}, limit int64, excludedFields map[string]interface{}, sort map[string]interface{}, results interface{}) error {
+	_, err := i.observe(ctx, "GetItems", collection, queryShape{Filter: filter, Projection: excludedFields, Sort: sort}, func(ctx context.Context) (float64, error) {
+		if err := i.MongoDB.GetItems(ctx, collection, filter, limit, excludedFields, sort, results); err != nil {
+			return 0, err
+		}
+		return 0, nil
+	})
+	return err
+}
+
+func (i *instrumentedDB) CountItems(ctx context.Context, collection string, filter map[string]interface{}) (int64, error) {
+	var count int64
+	_, err := i.observe(ctx, "CountItems", collection, queryShape{Filter: filter}, func(ctx context.Context) (float64, error) {
+		var err error
+		count, err = i.MongoDB.CountItems(ctx, collection, filter)
+		if err != nil {
+			return 0, err
+		}
+		return float64(count), nil
+	})
+	return count, err
+}
+
+func (i *instrumentedDB) DeleteItem(ctx context.Context, collection string, filter map[string]interface{}) (int64, error) {
+	var deleted int64
+	_, err := i.observe(ctx, "DeleteItem", collection, queryShape{Filter: filter}, func(ctx context.Context) (float64, error) {
+		var err error
+		deleted, err = i.MongoDB.DeleteItem(ctx, collection, filter)
+		if err != nil {
+			return 0, err
+		}
+		return float64(deleted), nil
+	})
+	return deleted, err
+}
+
+func (i *instrumentedDB) DeleteItems(ctx context.Context, collection string, filter map[string]interface{}) (int64, error) {
+	var deleted int64
+	_, err := i.observe(ctx, "DeleteItems", collection, queryShape{Filter: filter}, func(ctx context.Context) (float64, error) {
+		var err error
+		deleted, err = i.MongoDB.DeleteItems(ctx, collection, filter)
+		if err != nil {
+			return 0, err
+		}
+		return float64(deleted), nil
+	})
+	return deleted, err
+}
+
+func (i *instrumentedDB) UpdateItem(ctx context.Context, collection string, match map[string]interface{}, update map[string]interface{}) (int64, error) {
+	var modified int64
+	_, err := i.observe(ctx, "UpdateItem", collection, queryShape{Filter: match}, func(ctx context.Context) (float64, error) {
+		var err error
+		modified, err = i.MongoDB.UpdateItem(ctx, collection, match, update)
+		if err != nil {
+			return 0, err
+		}
+		return float64(modified), nil
+	})
+	return modified, err
+}
+
+func (i *instrumentedDB) UpdateItems(ctx context.Context, collection string, match map[string]interface{}, update map[string]interface{}) (int64, error) {
+	var modified int64
+	_, err := i.observe(ctx, "UpdateItems", collection, queryShape{Filter: match}, func(ctx context.Context) (float64, error) {
+		var err error
+		modified, err = i.MongoDB.UpdateItems(ctx, collection, match, update)
+		if err != nil {
+			return 0, err
+		}
+		return float64(modified), nil
+	})
+	return modified, err
+}
+
+func (i *instrumentedDB) WithTransaction(ctx context.Context, fn func(sessCtx context.Context) error, opts ...*options.TransactionOptions) error {
+	_, err := i.observe(ctx, "WithTransaction", "", queryShape{}, func(ctx context.Context) (float64, error) {
+		return 0, i.MongoDB.WithTransaction(ctx, fn, opts...)
+	})
+	return err
+}
+
+func (i *instrumentedDB) Aggregate(ctx context.Context, collection string, pipeline []bson.M, results interface{}) error {
+	_, err := i.observe(ctx, "Aggregate", collection, queryShape{Filter: pipeline}, func(ctx context.Context) (float64, error) {
+		if err := i.MongoDB.Aggregate(ctx, collection, pipeline, results); err != nil {
+			return 0, err
+		}
+		return 0, nil
+	})
+	return err
+}
+
+func (i *instrumentedDB) Watch(ctx context.Context, collection string, pipeline []bson.M, opts *WatchOptions) (*Watcher, error) {
+	var watcher *Watcher
+	_, err := i.observe(ctx, "Watch", collection, queryShape{Filter: pipeline}, func(ctx context.Context) (float64, error) {
+		var err error
+		watcher, err = i.MongoDB.Watch(ctx, collection, pipeline, opts)
+		return 0, err
+	})
+	return watcher, err
+}
+
+func (i *instrumentedDB) StartSession(opts ...*options.SessionOptions) (mongo.Session, error) {
+	return i.MongoDB.StartSession(opts...)
+}
+
+// Find/Insert/Update/Delete bind the returned builder to i rather than
+// i.MongoDB, so the builder's own observe calls (query.go) go through i's
+// span/metrics/slow-query logging instead of silently bypassing it via the
+// embedded *MongoDB.
+func (i *instrumentedDB) Find(collection string) *FindOperation {
+	return &FindOperation{db: i, collection: collection, filter: bson.M{}}
+}
+
+func (i *instrumentedDB) Insert(collection string) *InsertOperation {
+	return &InsertOperation{db: i, collection: collection}
+}
+
+func (i *instrumentedDB) Update(collection string) *UpdateOperation {
+	return &UpdateOperation{db: i, collection: collection, filter: bson.M{}}
+}
+
+func (i *instrumentedDB) Delete(collection string) *DeleteOperation {
+	return &DeleteOperation{db: i, collection: collection, filter: bson.M{}}
+}
+
+func (i *instrumentedDB) AggregateCursor(ctx context.Context, collection string, pipeline []bson.M, handler func(decode func(interface{}) error) error) error {
+	_, err := i.observe(ctx, "AggregateCursor", collection, queryShape{Filter: pipeline}, func(ctx context.Context) (float64, error) {
+		if err := i.MongoDB.AggregateCursor(ctx, collection, pipeline, handler); err != nil {
+			return 0, err
+		}
+		return 0, nil
+	})
+	return err
+}
+
+func (i *instrumentedDB) EnsureSchema(ctx context.Context, specs []CollectionSpec) error {
+	_, err := i.observe(ctx, "EnsureSchema", "", queryShape{}, func(ctx context.Context) (float64, error) {
+		return 0, i.MongoDB.EnsureSchema(ctx, specs)
+	})
+	return err
+}
+
+// Migrator caches the Migrator on i the first time it's called, mirroring
+// MongoDB.migrator, so migrations registered through an instrumented DB
+// accumulate into one Migrator too. It's built with db: i so Migrator.Run's
+// call to WithTransaction picks up instrumentation.
+func (i *instrumentedDB) Migrator() *Migrator {
+	i.migratorOnce.Do(func() {
+		i.migrator = &Migrator{db: i}
+	})
+	return i.migrator
+}
+
+func (i *instrumentedDB) ForceReconnect(ctx context.Context) error {
+	_, err := i.observe(ctx, "ForceReconnect", "", queryShape{}, func(ctx context.Context) (float64, error) {
+		return 0, i.MongoDB.ForceReconnect(ctx)
+	})
+	return err
+}
+
+// Healthy/LastError/StartHealthChecker are forwarded unwrapped rather than
+// through observe: they're cheap in-memory state reads and one-time setup,
+// not per-request DB operations, and have no natural per-call ctx to attach
+// a span to.
+func (i *instrumentedDB) Healthy() bool {
+	return i.MongoDB.Healthy()
+}
+
+func (i *instrumentedDB) LastError() error {
+	return i.MongoDB.LastError()
+}
+
+func (i *instrumentedDB) StartHealthChecker(opts HealthCheckOptions) *HealthChecker {
+	return i.MongoDB.StartHealthChecker(opts)
+}
@@ -0,0 +1,68 @@
+package docdb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestShapeOfReplacesValuesWithTypes(t *testing.T) {
+	got := shapeOf(map[string]interface{}{"email": "a@b.com", "age": 30})
+	want := map[string]string{"email": "string", "age": "int"}
+
+	if len(got.(map[string]string)) != len(want) {
+		t.Fatalf("shapeOf(%v) = %v, want %v", "map", got, want)
+	}
+	for k, v := range want {
+		if got.(map[string]string)[k] != v {
+			t.Fatalf("shapeOf: field %q = %q, want %q", k, got.(map[string]string)[k], v)
+		}
+	}
+}
+
+func TestShapeHashSameForDifferentValuesSameShape(t *testing.T) {
+	a := shapeHash(map[string]interface{}{"email": "a@b.com"})
+	b := shapeHash(map[string]interface{}{"email": "c@d.com"})
+	if a != b {
+		t.Fatalf("shapeHash should ignore values: %q != %q", a, b)
+	}
+}
+
+func TestShapeHashDifferentForDifferentFields(t *testing.T) {
+	a := shapeHash(map[string]interface{}{"email": "a@b.com"})
+	b := shapeHash(map[string]interface{}{"username": "a@b.com"})
+	if a == b {
+		t.Fatal("shapeHash should differ when the filter's fields differ")
+	}
+}
+
+func TestShapeHashNil(t *testing.T) {
+	if got := shapeHash(nil); got != "" {
+		t.Fatalf("shapeHash(nil) = %q, want empty string", got)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "not found", err: fmt.Errorf("wrap: %w", ErrNotFound), want: "not_found"},
+		{name: "duplicate", err: fmt.Errorf("wrap: %w", ErrMongoDBDuplicate), want: "duplicate"},
+		{name: "write conflict", err: fmt.Errorf("wrap: %w", ErrWriteConflict), want: "write_conflict"},
+		{name: "network", err: fmt.Errorf("wrap: %w", ErrNetwork), want: "network"},
+		{name: "timeout", err: fmt.Errorf("wrap: %w", ErrTimeout), want: "timeout"},
+		{name: "invalid object id", err: fmt.Errorf("wrap: %w", ErrInvalidObjectID), want: "invalid_object_id"},
+		{name: "unrecognized", err: errors.New("boom"), want: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorClass(tt.err); got != tt.want {
+				t.Fatalf("errorClass(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package docdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationsCollection stores both the applied CollectionSpec checksums from
+// EnsureSchema and the applied version numbers from Migrator.Run.
+const migrationsCollection = "_docdb_migrations"
+
+// IndexSpec declares a single index to ensure exists on a collection.
+type IndexSpec struct {
+	// Name is optional; when empty, mongo derives one from Keys.
+	Name string
+	// Keys is the index key document, e.g. bson.D{{"email", 1}}.
+	Keys          bson.D
+	Unique        bool
+	PartialFilter bson.M
+	// TTL, when non-zero, makes this a TTL index that expires documents
+	// TTL after the indexed timestamp field.
+	TTL       time.Duration
+	Collation *options.Collation
+}
+
+// CollectionSpec declares the desired shape of one collection: its indexes,
+// an optional $jsonSchema validator, and capped-collection options.
+type CollectionSpec struct {
+	Name    string
+	Indexes []IndexSpec
+
+	// Validator, if set, is installed as the collection's $jsonSchema
+	// validator.
+	Validator       bson.M
+	ValidationLevel string // "off", "strict" (default), or "moderate"
+
+	Capped     bool
+	CappedSize int64 // bytes, required when Capped is true
+	CappedMax  int64 // optional max document count
+}
+
+// migrationRecord is the document persisted to migrationsCollection.
+type migrationRecord struct {
+	ID        interface{} `bson:"_id"`
+	Checksum  string      `bson:"checksum,omitempty"`
+	AppliedAt time.Time   `bson:"appliedAt"`
+}
+
+// EnsureSchema applies each CollectionSpec's indexes, validator, and
+// capped-collection options. It is idempotent: a spec is skipped if its
+// checksum already matches what was last applied, so repeated calls (e.g.
+// on every service startup) are cheap.
+func (db *MongoDB) EnsureSchema(ctx context.Context, specs []CollectionSpec) error {
+	migrations := db.GetCollection(migrationsCollection)
+
+	for _, spec := range specs {
+		checksum := checksumCollectionSpec(spec)
+		recordID := "schema:" + spec.Name
+
+		var existing migrationRecord
+		err := migrations.FindOne(ctx, bson.M{"_id": recordID}).Decode(&existing)
+		if err == nil && existing.Checksum == checksum {
+			continue
+		}
+		if err != nil && err != mongo.ErrNoDocuments {
+			return classifyError(err)
+		}
+
+		if err := db.applyCollectionSpec(ctx, spec); err != nil {
+			return err
+		}
+
+		_, err = migrations.UpdateOne(ctx,
+			bson.M{"_id": recordID},
+			bson.M{"$set": migrationRecord{ID: recordID, Checksum: checksum, AppliedAt: time.Now()}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return classifyError(err)
+		}
+	}
+
+	return nil
+}
+
+func (db *MongoDB) applyCollectionSpec(ctx context.Context, spec CollectionSpec) error {
+	if err := db.createCollectionIfNeeded(ctx, spec); err != nil {
+		return err
+	}
+
+	if len(spec.Indexes) > 0 {
+		coll := db.GetCollection(spec.Name)
+		models := make([]mongo.IndexModel, 0, len(spec.Indexes))
+		for _, idx := range spec.Indexes {
+			idxOpts := options.Index()
+			if idx.Name != "" {
+				idxOpts.SetName(idx.Name)
+			}
+			if idx.Unique {
+				idxOpts.SetUnique(true)
+			}
+			if idx.PartialFilter != nil {
+				idxOpts.SetPartialFilterExpression(idx.PartialFilter)
+			}
+			if idx.TTL > 0 {
+				idxOpts.SetExpireAfterSeconds(int32(idx.TTL.Seconds()))
+			}
+			if idx.Collation != nil {
+				idxOpts.SetCollation(idx.Collation)
+			}
+			models = append(models, mongo.IndexModel{Keys: idx.Keys, Options: idxOpts})
+		}
+		if _, err := coll.Indexes().CreateMany(ctx, models); err != nil {
+			return classifyError(err)
+		}
+	}
+
+	return nil
+}
+
+// createCollectionIfNeeded explicitly creates the collection via
+// runCommand when a validator or capped options are declared, since those
+// can only be set at creation time (or via collMod for the validator).
+func (db *MongoDB) createCollectionIfNeeded(ctx context.Context, spec CollectionSpec) error {
+	if spec.Validator == nil && !spec.Capped {
+		return nil
+	}
+
+	cmd := bson.D{{Key: "create", Value: spec.Name}}
+	if spec.Capped {
+		cmd = append(cmd, bson.E{Key: "capped", Value: true}, bson.E{Key: "size", Value: spec.CappedSize})
+		if spec.CappedMax > 0 {
+			cmd = append(cmd, bson.E{Key: "max", Value: spec.CappedMax})
+		}
+	}
+	if spec.Validator != nil {
+		cmd = append(cmd, bson.E{Key: "validator", Value: bson.M{"$jsonSchema": spec.Validator}})
+		level := spec.ValidationLevel
+		if level == "" {
+			level = "strict"
+		}
+		cmd = append(cmd, bson.E{Key: "validationLevel", Value: level})
+	}
+
+	err := db.GetClient().Database(db.database).RunCommand(ctx, cmd).Err()
+	if err == nil {
+		return nil
+	}
+
+	// NamespaceExists: collection is already there. If a validator is
+	// declared, install it with collMod; capped options can't be changed after
+	// creation, so we leave an existing non-capped collection as-is.
+	if strings.Contains(err.Error(), "NamespaceExists") {
+		if spec.Validator != nil {
+			modCmd := bson.D{
+				{Key: "collMod", Value: spec.Name},
+				{Key: "validator", Value: bson.M{"$jsonSchema": spec.Validator}},
+			}
+			return classifyError(db.GetClient().Database(db.database).RunCommand(ctx, modCmd).Err())
+		}
+		return nil
+	}
+
+	return classifyError(err)
+}
+
+// checksumCollectionSpec hashes the parts of spec that affect what
+// EnsureSchema applies, so changing an index or validator is detected even
+// though spec.Name (the record key) stays the same.
+func checksumCollectionSpec(spec CollectionSpec) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "name=%s capped=%v size=%d max=%d level=%s\n",
+		spec.Name, spec.Capped, spec.CappedSize, spec.CappedMax, spec.ValidationLevel)
+
+	indexes := make([]string, len(spec.Indexes))
+	for i, idx := range spec.Indexes {
+		indexes[i] = fmt.Sprintf("name=%s keys=%v unique=%v partial=%v ttl=%s collation=%v",
+			idx.Name, idx.Keys, idx.Unique, idx.PartialFilter, idx.TTL, idx.Collation)
+	}
+	sort.Strings(indexes)
+	for _, line := range indexes {
+		fmt.Fprintln(h, line)
+	}
+
+	if spec.Validator != nil {
+		fmt.Fprintf(h, "validator=%v\n", spec.Validator)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Migration is a single numbered up-migration registered with Migrator.
+type Migration struct {
+	Version int
+	Fn      func(sessCtx context.Context) error
+}
+
+// Migrator runs numbered up-migrations, recording applied versions in
+// migrationsCollection so each one runs at most once. db is a DBIntf rather
+// than a *MongoDB so a Migrator obtained from an instrumented DB runs its
+// transactions (runOne, via WithTransaction) through that instrumentation.
+type Migrator struct {
+	db         DBIntf
+	migrations []Migration
+}
+
+// Migrator returns the Migrator for db, creating it on the first call and
+// reusing it on every later one, so independent packages registering
+// migrations against the same db accumulate into one Migrator instead of
+// each resetting it.
+func (db *MongoDB) Migrator() *Migrator {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.migrator == nil {
+		db.migrator = &Migrator{db: db}
+	}
+	return db.migrator
+}
+
+// Register adds fn as the up-migration for version. Versions are applied in
+// ascending order by Run, each one at most once.
+func (m *Migrator) Register(version int, fn func(sessCtx context.Context) error) {
+	m.migrations = append(m.migrations, Migration{Version: version, Fn: fn})
+}
+
+// Run applies every registered migration whose version hasn't been recorded
+// in migrationsCollection yet, in ascending version order. Each migration
+// runs inside a transaction when the deployment is a replica set (so a
+// failing migration leaves no partial effect); on a standalone deployment,
+// where transactions aren't supported, it runs directly against ctx.
+//
+// Before running a migration, Run reserves its slot by inserting the record
+// up front. That insert's unique _id makes the reservation atomic across
+// processes: if two replicas race Run at startup, only one wins the insert
+// and actually executes migration.Fn, so the same migration is never applied
+// twice. The loser sees a duplicate-key error on the reservation insert,
+// treats it as "someone else is applying this one", and moves on. If the
+// winner's migration fails, its reservation is removed so a later Run can
+// retry it.
+func (m *Migrator) Run(ctx context.Context) error {
+	sort.Slice(m.migrations, func(i, j int) bool { return m.migrations[i].Version < m.migrations[j].Version })
+
+	migrations := m.db.GetCollection(migrationsCollection)
+
+	for _, migration := range m.migrations {
+		recordID := fmt.Sprintf("version:%d", migration.Version)
+
+		reserved, err := reserveMigrationSlot(ctx, migrations, recordID)
+		if err != nil {
+			return err
+		}
+		if !reserved {
+			continue
+		}
+
+		if err := m.runOne(ctx, migration); err != nil {
+			_, _ = migrations.DeleteOne(ctx, bson.M{"_id": recordID})
+			return fmt.Errorf("docdb: migration %d: %w", migration.Version, err)
+		}
+	}
+
+	return nil
+}
+
+// reserveMigrationSlot tries to claim recordID by inserting its record
+// before the migration it guards runs. It reports true if this call won the
+// reservation, false if another process already holds it (a duplicate-key
+// error on the insert), and a non-nil error for anything else.
+func reserveMigrationSlot(ctx context.Context, migrations *mongo.Collection, recordID string) (bool, error) {
+	_, err := migrations.InsertOne(ctx, migrationRecord{ID: recordID, AppliedAt: time.Now()})
+	if err == nil {
+		return true, nil
+	}
+
+	classified := classifyError(err)
+	if errors.Is(classified, ErrMongoDBDuplicate) {
+		return false, nil
+	}
+	return false, classified
+}
+
+func (m *Migrator) runOne(ctx context.Context, migration Migration) error {
+	err := m.db.WithTransaction(ctx, migration.Fn)
+	if err == nil {
+		return nil
+	}
+	if isTransactionsNotSupported(err) {
+		return migration.Fn(ctx)
+	}
+	return err
+}
+
+// isTransactionsNotSupported reports whether err is the driver's way of
+// saying the deployment (e.g. a standalone mongod) doesn't support
+// transactions.
+func isTransactionsNotSupported(err error) bool {
+	return strings.Contains(err.Error(), "Transaction numbers") ||
+		strings.Contains(err.Error(), "IllegalOperation")
+}
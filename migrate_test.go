@@ -0,0 +1,73 @@
+package docdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestChecksumCollectionSpecStableForSameSpec(t *testing.T) {
+	spec := CollectionSpec{
+		Name: "users",
+		Indexes: []IndexSpec{
+			{Name: "by_email", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+			{Name: "by_created_at", Keys: bson.D{{Key: "createdAt", Value: 1}}, TTL: 24 * time.Hour},
+		},
+		Validator: bson.M{"required": []string{"email"}},
+	}
+
+	a := checksumCollectionSpec(spec)
+	b := checksumCollectionSpec(spec)
+	if a != b {
+		t.Fatalf("checksumCollectionSpec is not stable: %q != %q", a, b)
+	}
+}
+
+func TestChecksumCollectionSpecIgnoresIndexOrder(t *testing.T) {
+	idxA := IndexSpec{Name: "by_email", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true}
+	idxB := IndexSpec{Name: "by_name", Keys: bson.D{{Key: "name", Value: 1}}}
+
+	spec1 := CollectionSpec{Name: "users", Indexes: []IndexSpec{idxA, idxB}}
+	spec2 := CollectionSpec{Name: "users", Indexes: []IndexSpec{idxB, idxA}}
+
+	if checksumCollectionSpec(spec1) != checksumCollectionSpec(spec2) {
+		t.Fatal("checksumCollectionSpec should be independent of Indexes order")
+	}
+}
+
+func TestChecksumCollectionSpecChangesWithIndex(t *testing.T) {
+	base := CollectionSpec{
+		Name:    "users",
+		Indexes: []IndexSpec{{Name: "by_email", Keys: bson.D{{Key: "email", Value: 1}}}},
+	}
+	changed := CollectionSpec{
+		Name:    "users",
+		Indexes: []IndexSpec{{Name: "by_email", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true}},
+	}
+
+	if checksumCollectionSpec(base) == checksumCollectionSpec(changed) {
+		t.Fatal("checksumCollectionSpec should change when an index's options change")
+	}
+}
+
+func TestIsTransactionsNotSupported(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "transaction numbers message", err: errors.New("Transaction numbers are only allowed on a replica set member or mongos"), want: true},
+		{name: "illegal operation message", err: errors.New("IllegalOperation: Transaction numbers"), want: true},
+		{name: "unrelated error", err: errors.New("connection refused"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransactionsNotSupported(tt.err); got != tt.want {
+				t.Fatalf("isTransactionsNotSupported(%q) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
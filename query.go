@@ -0,0 +1,393 @@
+package docdb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindOperation is a fluent builder over mongo.Collection.Find/FindOne. It
+// replaces the positional-parameter signature of GetItems/GetItem for
+// callers that need pagination, hints, or collation; GetItems/GetItem remain
+// thin wrappers around it for backwards compatibility.
+type FindOperation struct {
+	db         dbCore
+	collection string
+	filter     bson.M
+	projection bson.M
+	sort       bson.M
+	skip       *int64
+	limit      *int64
+	hint       interface{}
+	collation  *options.Collation
+}
+
+// Find starts a FindOperation against collection.
+func (db *MongoDB) Find(collection string) *FindOperation {
+	return &FindOperation{db: db, collection: collection, filter: bson.M{}}
+}
+
+// Where sets the query filter.
+func (f *FindOperation) Where(filter bson.M) *FindOperation {
+	f.filter = filter
+	return f
+}
+
+// Project sets the field projection (the same shape as excludedFields in
+// GetItem/GetItems).
+func (f *FindOperation) Project(projection bson.M) *FindOperation {
+	f.projection = projection
+	return f
+}
+
+// Sort sets the sort order.
+func (f *FindOperation) Sort(sort bson.M) *FindOperation {
+	f.sort = sort
+	return f
+}
+
+// Skip sets the number of matching documents to skip, for pagination.
+func (f *FindOperation) Skip(n int64) *FindOperation {
+	f.skip = &n
+	return f
+}
+
+// Limit caps the number of documents returned.
+func (f *FindOperation) Limit(n int64) *FindOperation {
+	f.limit = &n
+	return f
+}
+
+// Hint forces the query planner to use a specific index.
+func (f *FindOperation) Hint(hint interface{}) *FindOperation {
+	f.hint = hint
+	return f
+}
+
+// Collation sets a non-default collation for the query.
+func (f *FindOperation) Collation(collation *options.Collation) *FindOperation {
+	f.collation = collation
+	return f
+}
+
+func (f *FindOperation) findOptions() *options.FindOptions {
+	opts := options.Find().SetProjection(f.projection).SetSort(f.sort)
+	if f.skip != nil {
+		opts.SetSkip(*f.skip)
+	}
+	if f.limit != nil {
+		opts.SetLimit(*f.limit)
+	}
+	if f.hint != nil {
+		opts.SetHint(f.hint)
+	}
+	if f.collation != nil {
+		opts.SetCollation(f.collation)
+	}
+	return opts
+}
+
+func (f *FindOperation) queryShape() queryShape {
+	return queryShape{Filter: f.filter, Projection: f.projection, Sort: f.sort}
+}
+
+// All runs the query and decodes every matching document into results, a
+// pointer to a slice.
+func (f *FindOperation) All(ctx context.Context, results interface{}) error {
+	_, err := f.db.observe(ctx, "Find.All", f.collection, f.queryShape(), func(ctx context.Context) (float64, error) {
+		collection := f.db.GetCollection(f.collection)
+
+		cur, err := collection.Find(ctx, f.filter, f.findOptions())
+		if err != nil {
+			return 0, classifyError(err)
+		}
+		defer cur.Close(ctx)
+
+		if err := cur.All(ctx, results); err != nil {
+			return 0, classifyError(err)
+		}
+		return 0, nil
+	})
+	return err
+}
+
+// One runs the query and decodes the first matching document into result.
+func (f *FindOperation) One(ctx context.Context, result interface{}) error {
+	_, err := f.db.observe(ctx, "Find.One", f.collection, f.queryShape(), func(ctx context.Context) (float64, error) {
+		collection := f.db.GetCollection(f.collection)
+
+		findOneOpts := options.FindOne().SetProjection(f.projection).SetSort(f.sort)
+		if f.skip != nil {
+			findOneOpts.SetSkip(*f.skip)
+		}
+		if f.hint != nil {
+			findOneOpts.SetHint(f.hint)
+		}
+		if f.collation != nil {
+			findOneOpts.SetCollation(f.collation)
+		}
+
+		if err := collection.FindOne(ctx, f.filter, findOneOpts).Decode(result); err != nil {
+			return 0, classifyError(err)
+		}
+		return 1, nil
+	})
+	return err
+}
+
+// Count returns the number of documents matching the query, ignoring Skip/
+// Limit/Sort/Project.
+func (f *FindOperation) Count(ctx context.Context) (int64, error) {
+	var count int64
+	_, err := f.db.observe(ctx, "Find.Count", f.collection, f.queryShape(), func(ctx context.Context) (float64, error) {
+		collection := f.db.GetCollection(f.collection)
+
+		var err error
+		count, err = collection.CountDocuments(ctx, f.filter)
+		if err != nil {
+			return 0, classifyError(err)
+		}
+		return float64(count), nil
+	})
+	return count, err
+}
+
+// Stream runs the query and invokes handler once per matching document,
+// without buffering the whole result set in memory like All does. Iteration
+// stops at the first error handler returns.
+func (f *FindOperation) Stream(ctx context.Context, handler func(decode func(interface{}) error) error) error {
+	_, err := f.db.observe(ctx, "Find.Stream", f.collection, f.queryShape(), func(ctx context.Context) (float64, error) {
+		collection := f.db.GetCollection(f.collection)
+
+		cur, err := collection.Find(ctx, f.filter, f.findOptions())
+		if err != nil {
+			return 0, classifyError(err)
+		}
+		defer cur.Close(ctx)
+
+		for cur.Next(ctx) {
+			if err := handler(cur.Decode); err != nil {
+				return 0, err
+			}
+		}
+		return 0, classifyError(cur.Err())
+	})
+	return err
+}
+
+// InsertOperation is a fluent builder over mongo.Collection.InsertOne/
+// InsertMany.
+type InsertOperation struct {
+	db         dbCore
+	collection string
+}
+
+// Insert starts an InsertOperation against collection.
+func (db *MongoDB) Insert(collection string) *InsertOperation {
+	return &InsertOperation{db: db, collection: collection}
+}
+
+// One inserts a single document and returns its hex-encoded ObjectID.
+func (i *InsertOperation) One(ctx context.Context, data interface{}) (string, error) {
+	var id string
+	_, err := i.db.observe(ctx, "Insert.One", i.collection, queryShape{}, func(ctx context.Context) (float64, error) {
+		collection := i.db.GetCollection(i.collection)
+
+		insertResult, err := collection.InsertOne(ctx, data)
+		if err != nil {
+			return 0, classifyError(err)
+		}
+		id = insertResult.InsertedID.(primitive.ObjectID).Hex()
+		return 1, nil
+	})
+	return id, err
+}
+
+// Many inserts several documents at once and returns their generated IDs.
+func (i *InsertOperation) Many(ctx context.Context, data []interface{}) ([]interface{}, error) {
+	var ids []interface{}
+	_, err := i.db.observe(ctx, "Insert.Many", i.collection, queryShape{}, func(ctx context.Context) (float64, error) {
+		collection := i.db.GetCollection(i.collection)
+
+		insertManyResult, err := collection.InsertMany(ctx, data)
+		if err != nil {
+			return 0, classifyError(err)
+		}
+		ids = insertManyResult.InsertedIDs
+		return float64(len(ids)), nil
+	})
+	return ids, err
+}
+
+// UpdateOperation is a fluent builder over mongo.Collection.UpdateOne/
+// UpdateMany, replacing the growing list of positional parameters with
+// chained setters for upsert, array filters, and collation.
+type UpdateOperation struct {
+	db            dbCore
+	collection    string
+	filter        bson.M
+	update        bson.M
+	upsert        bool
+	arrayFilters  []interface{}
+	hasArrFilters bool
+	collation     *options.Collation
+}
+
+// Update starts an UpdateOperation against collection.
+func (db *MongoDB) Update(collection string) *UpdateOperation {
+	return &UpdateOperation{db: db, collection: collection, filter: bson.M{}}
+}
+
+// Where sets the match filter.
+func (u *UpdateOperation) Where(filter bson.M) *UpdateOperation {
+	u.filter = filter
+	return u
+}
+
+// Apply sets the update document (a $set/$inc/... modifier, or a
+// replacement document).
+func (u *UpdateOperation) Apply(update bson.M) *UpdateOperation {
+	u.update = update
+	return u
+}
+
+// Upsert makes the update insert a new document when nothing matches.
+func (u *UpdateOperation) Upsert(upsert bool) *UpdateOperation {
+	u.upsert = upsert
+	return u
+}
+
+// ArrayFilters sets the array filters used to target elements in array
+// fields referenced by positional operators in Apply.
+func (u *UpdateOperation) ArrayFilters(filters []interface{}) *UpdateOperation {
+	u.arrayFilters = filters
+	u.hasArrFilters = true
+	return u
+}
+
+// Collation sets a non-default collation for the update.
+func (u *UpdateOperation) Collation(collation *options.Collation) *UpdateOperation {
+	u.collation = collation
+	return u
+}
+
+func (u *UpdateOperation) updateOptions() *options.UpdateOptions {
+	opts := options.Update().SetUpsert(u.upsert)
+	if u.hasArrFilters {
+		opts.SetArrayFilters(options.ArrayFilters{Filters: u.arrayFilters})
+	}
+	if u.collation != nil {
+		opts.SetCollation(u.collation)
+	}
+	return opts
+}
+
+// One updates the first document matching Where and returns the modified
+// count, or 1 when Upsert inserted a new document instead.
+func (u *UpdateOperation) One(ctx context.Context) (int64, error) {
+	var modified int64
+	_, err := u.db.observe(ctx, "Update.One", u.collection, queryShape{Filter: u.filter}, func(ctx context.Context) (float64, error) {
+		collection := u.db.GetCollection(u.collection)
+
+		result, err := collection.UpdateOne(ctx, u.filter, u.update, u.updateOptions())
+		if err != nil {
+			return 0, classifyError(err)
+		}
+		if u.upsert && result.ModifiedCount == 0 && result.UpsertedID != nil {
+			modified = 1
+		} else {
+			modified = result.ModifiedCount
+		}
+		return float64(modified), nil
+	})
+	return modified, err
+}
+
+// Many updates every document matching Where and returns the modified count,
+// or 1 when Upsert inserted a new document instead.
+func (u *UpdateOperation) Many(ctx context.Context) (int64, error) {
+	var modified int64
+	_, err := u.db.observe(ctx, "Update.Many", u.collection, queryShape{Filter: u.filter}, func(ctx context.Context) (float64, error) {
+		collection := u.db.GetCollection(u.collection)
+
+		result, err := collection.UpdateMany(ctx, u.filter, u.update, u.updateOptions())
+		if err != nil {
+			return 0, classifyError(err)
+		}
+		if u.upsert && result.ModifiedCount == 0 && result.UpsertedID != nil {
+			modified = 1
+		} else {
+			modified = result.ModifiedCount
+		}
+		return float64(modified), nil
+	})
+	return modified, err
+}
+
+// DeleteOperation is a fluent builder over mongo.Collection.DeleteOne/
+// DeleteMany.
+type DeleteOperation struct {
+	db         dbCore
+	collection string
+	filter     bson.M
+	collation  *options.Collation
+}
+
+// Delete starts a DeleteOperation against collection.
+func (db *MongoDB) Delete(collection string) *DeleteOperation {
+	return &DeleteOperation{db: db, collection: collection, filter: bson.M{}}
+}
+
+// Where sets the delete filter.
+func (d *DeleteOperation) Where(filter bson.M) *DeleteOperation {
+	d.filter = filter
+	return d
+}
+
+// Collation sets a non-default collation for the delete.
+func (d *DeleteOperation) Collation(collation *options.Collation) *DeleteOperation {
+	d.collation = collation
+	return d
+}
+
+func (d *DeleteOperation) deleteOptions() *options.DeleteOptions {
+	opts := options.Delete()
+	if d.collation != nil {
+		opts.SetCollation(d.collation)
+	}
+	return opts
+}
+
+// One deletes the first document matching Where and returns the deleted count.
+func (d *DeleteOperation) One(ctx context.Context) (int64, error) {
+	var deleted int64
+	_, err := d.db.observe(ctx, "Delete.One", d.collection, queryShape{Filter: d.filter}, func(ctx context.Context) (float64, error) {
+		collection := d.db.GetCollection(d.collection)
+
+		result, err := collection.DeleteOne(ctx, d.filter, d.deleteOptions())
+		if err != nil {
+			return 0, classifyError(err)
+		}
+		deleted = result.DeletedCount
+		return float64(deleted), nil
+	})
+	return deleted, err
+}
+
+// Many deletes every document matching Where and returns the deleted count.
+func (d *DeleteOperation) Many(ctx context.Context) (int64, error) {
+	var deleted int64
+	_, err := d.db.observe(ctx, "Delete.Many", d.collection, queryShape{Filter: d.filter}, func(ctx context.Context) (float64, error) {
+		collection := d.db.GetCollection(d.collection)
+
+		result, err := collection.DeleteMany(ctx, d.filter, d.deleteOptions())
+		if err != nil {
+			return 0, classifyError(err)
+		}
+		deleted = result.DeletedCount
+		return float64(deleted), nil
+	})
+	return deleted, err
+}
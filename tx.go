@@ -0,0 +1,37 @@
+package docdb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithTransaction starts a mongo session and runs fn inside session.WithTransaction.
+// fn receives a context derived from the session (a mongo.SessionContext) and must
+// pass it on to any Save/Update*/Delete* calls that should participate in the
+// transaction. The transaction is committed if fn returns nil and aborted otherwise.
+func (db *MongoDB) WithTransaction(ctx context.Context, fn func(sessCtx context.Context) error, opts ...*options.TransactionOptions) error {
+	session, err := db.GetClient().StartSession()
+	if err != nil {
+		return classifyError(err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	}, opts...)
+
+	return classifyError(err)
+}
+
+// StartSession starts a mongo session for callers that need manual control over
+// commit/abort rather than the WithTransaction helper, e.g. multi-step writes
+// spanning several collections.
+func (db *MongoDB) StartSession(opts ...*options.SessionOptions) (mongo.Session, error) {
+	session, err := db.GetClient().StartSession(opts...)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return session, nil
+}
@@ -0,0 +1,145 @@
+package docdb
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ChangeEvent is a decoded change-stream document.
+type ChangeEvent struct {
+	OperationType string
+	FullDocument  bson.Raw
+	DocumentKey   bson.Raw
+	ResumeToken   bson.Raw
+	Raw           bson.Raw
+}
+
+// ResumeTokenStore persists change-stream resume tokens so a Watch can pick
+// up where it left off across restarts instead of replaying or missing
+// events. StreamID lets a single store back multiple independent streams.
+type ResumeTokenStore interface {
+	SaveResumeToken(ctx context.Context, streamID string, token bson.Raw) error
+	LoadResumeToken(ctx context.Context, streamID string) (bson.Raw, error)
+}
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// StreamID identifies this stream to ResumeTokenStore. Required when
+	// ResumeTokenStore is set.
+	StreamID string
+	// ResumeTokenStore, if set, is consulted for a resume token before the
+	// stream opens, and updated after every event the stream delivers.
+	ResumeTokenStore ResumeTokenStore
+	// FullDocument controls whether update events include the full current
+	// document (see options.UpdateLookup).
+	FullDocument options.FullDocument
+}
+
+// Watcher is the handle Watch returns. Events delivers decoded change-stream
+// documents; the channel is closed when ctx is canceled or the stream ends.
+// Call Err after the channel closes: it returns nil for a clean shutdown
+// (ctx canceled, or the driver cursor simply ran out) and the classified
+// error if the stream ended because of a failure, so callers can tell the
+// two apart without reading the context themselves.
+type Watcher struct {
+	Events <-chan ChangeEvent
+
+	mu  sync.Mutex
+	err error
+}
+
+func (w *Watcher) setErr(err error) {
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+}
+
+// Err returns the error that ended the change stream, or nil if it ended
+// cleanly.
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Watch opens a change stream against collection filtered by pipeline and
+// returns a Watcher delivering decoded events. The Watcher's Events channel
+// is closed when ctx is canceled or the stream errors; callers should check
+// Watcher.Err() after the channel closes to tell the two apart.
+func (db *MongoDB) Watch(ctx context.Context, collection string, pipeline []bson.M, opts *WatchOptions) (*Watcher, error) {
+	coll := db.GetCollection(collection)
+
+	streamOpts := options.ChangeStream()
+	if opts != nil && opts.FullDocument != "" {
+		streamOpts.SetFullDocument(opts.FullDocument)
+	}
+
+	if opts != nil && opts.ResumeTokenStore != nil {
+		token, err := opts.ResumeTokenStore.LoadResumeToken(ctx, opts.StreamID)
+		if err != nil {
+			return nil, err
+		}
+		if token != nil {
+			streamOpts.SetResumeAfter(token)
+		}
+	}
+
+	mongoPipeline := make([]bson.M, len(pipeline))
+	copy(mongoPipeline, pipeline)
+
+	stream, err := coll.Watch(ctx, mongoPipeline, streamOpts)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+
+	events := make(chan ChangeEvent)
+	watcher := &Watcher{Events: events}
+	go func() {
+		defer close(events)
+		defer stream.Close(ctx)
+
+		for stream.Next(ctx) {
+			var raw bson.Raw
+			if err := stream.Decode(&raw); err != nil {
+				watcher.setErr(classifyError(err))
+				return
+			}
+
+			event := ChangeEvent{
+				OperationType: raw.Lookup("operationType").StringValue(),
+				ResumeToken:   bson.Raw(stream.ResumeToken()),
+				Raw:           raw,
+			}
+			if fullDoc, err := raw.LookupErr("fullDocument"); err == nil {
+				if doc, ok := fullDoc.DocumentOK(); ok {
+					event.FullDocument = doc
+				}
+			}
+			if docKey, err := raw.LookupErr("documentKey"); err == nil {
+				if doc, ok := docKey.DocumentOK(); ok {
+					event.DocumentKey = doc
+				}
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+
+			if opts != nil && opts.ResumeTokenStore != nil {
+				_ = opts.ResumeTokenStore.SaveResumeToken(ctx, opts.StreamID, event.ResumeToken)
+			}
+		}
+
+		// A canceled ctx also makes the stream fail; that is a clean shutdown.
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			watcher.setErr(classifyError(err))
+		}
+	}()
+
+	return watcher, nil
+}